@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMediaCaptureTimeFallsBackToModTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "screenshot.png")
+	if err := os.WriteFile(path, []byte("not a real image"), 0644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+	want := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, want, want); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	got, fromEXIF, ok := mediaCaptureTime(path)
+	if !ok {
+		t.Fatalf("expected ok=true for an existing file")
+	}
+	if fromEXIF {
+		t.Fatalf("expected fromEXIF=false for a file with no EXIF data")
+	}
+	if !got.Equal(want) {
+		t.Fatalf("CaptureTime = %v, want %v", got, want)
+	}
+}
+
+func TestProcessEntryHTMLPrefersHeaderDateOverMediaModTime(t *testing.T) {
+	entriesDir := t.TempDir()
+	htmlPath := filepath.Join(entriesDir, "entry.html")
+	photoPath := filepath.Join(entriesDir, "photo.png")
+
+	const html = `<html><body>
+<div class="pageContainer">
+<div class="pageHeader">Wednesday, May 14, 2025</div>
+<div class="title">Test Entry</div>
+<div class="assetGrid">
+<div class="gridItem assetType_photo"><img class="asset_image" src="photo.png"></div>
+</div>
+</div>
+</body></html>`
+	if err := os.WriteFile(htmlPath, []byte(html), 0644); err != nil {
+		t.Fatalf("writing entry.html: %v", err)
+	}
+	if err := os.WriteFile(photoPath, []byte("not a real image"), 0644); err != nil {
+		t.Fatalf("writing photo.png: %v", err)
+	}
+	// The photo's mtime is extraction time ("now"-like), far from the
+	// header date; without EXIF data it must not outrank the header date.
+	extractionTime := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(photoPath, extractionTime, extractionTime); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	outputDir := t.TempDir()
+	if err := prepOutput(outputDir); err != nil {
+		t.Fatalf("prepOutput: %v", err)
+	}
+
+	entry, err := processEntryHTML(htmlPath, entriesDir, outputDir, nil, true)
+	if err != nil {
+		t.Fatalf("processEntryHTML: %v", err)
+	}
+
+	want := time.Date(2025, 5, 14, 12, 0, 0, 0, time.UTC)
+	if !entry.CreationDate.Equal(want) {
+		t.Fatalf("CreationDate = %v, want %v (header date should win over a mtime-only fallback)", entry.CreationDate, want)
+	}
+}
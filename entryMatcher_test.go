@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExcludesMediaMatchesBasenameAcrossDirectories(t *testing.T) {
+	m, err := NewEntryMatcher(t.TempDir(), nil, []string{"*.mov"})
+	if err != nil {
+		t.Fatalf("NewEntryMatcher: %v", err)
+	}
+
+	if !m.ExcludesMedia("sub/resources/clip.mov") {
+		t.Fatalf("expected -exclude '*.mov' to match a nested clip.mov via basename fallback")
+	}
+	if m.ExcludesMedia("sub/resources/photo.jpg") {
+		t.Fatalf("did not expect -exclude '*.mov' to match photo.jpg")
+	}
+}
+
+func TestAllowEntryRespectsJournalIgnore(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".journalignore"), []byte("drafts/*\n"), 0644); err != nil {
+		t.Fatalf("writing .journalignore: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "drafts"), 0755); err != nil {
+		t.Fatalf("mkdir drafts: %v", err)
+	}
+
+	m, err := NewEntryMatcher(root, nil, nil)
+	if err != nil {
+		t.Fatalf("NewEntryMatcher: %v", err)
+	}
+
+	if !m.AllowEntry(root, filepath.Join(root, "entry.html")) {
+		t.Fatalf("expected a plain entry to be allowed")
+	}
+	if m.AllowEntry(root, filepath.Join(root, "drafts", "entry.html")) {
+		t.Fatalf("expected .journalignore to exclude entries matched by drafts/*")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObsidianExporterRewritesImagesAndBuildsIndex(t *testing.T) {
+	outputDir := t.TempDir()
+	tmpl, err := loadTemplate("")
+	if err != nil {
+		t.Fatalf("loadTemplate: %v", err)
+	}
+	e := &ObsidianExporter{OutputDir: outputDir, Render: RenderOptions{FrontMatter: FrontMatterNone, Template: tmpl}}
+
+	entry := MarkdownEntry{
+		Title:        "My Entry",
+		MarkdownText: "![](media/ab/abcdef.jpg)\n\nSome text.",
+		CreationDate: time.Date(2025, 5, 14, 12, 0, 0, 0, time.UTC),
+	}
+	if err := e.WriteEntry(entry); err != nil {
+		t.Fatalf("WriteEntry: %v", err)
+	}
+	if err := e.Finalize(); err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+
+	relPath := e.PlannedPath(entry)
+	written, err := os.ReadFile(filepath.Join(outputDir, relPath))
+	if err != nil {
+		t.Fatalf("reading written entry: %v", err)
+	}
+	if !strings.Contains(string(written), "![[media/ab/abcdef.jpg]]") {
+		t.Fatalf("expected a wiki-link image embed, got:\n%s", written)
+	}
+
+	index, err := os.ReadFile(filepath.Join(outputDir, "_index.md"))
+	if err != nil {
+		t.Fatalf("reading _index.md: %v", err)
+	}
+	if !strings.Contains(string(index), "My Entry") {
+		t.Fatalf("expected _index.md to link the entry's title, got:\n%s", index)
+	}
+}
+
+func TestDayOneExporterBundlesEntriesIntoJournalJSON(t *testing.T) {
+	outputDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(outputDir, "media", "ab"), 0755); err != nil {
+		t.Fatalf("mkdir media: %v", err)
+	}
+	photoPath := filepath.Join(outputDir, "media", "ab", "abcdef.jpg")
+	if err := os.WriteFile(photoPath, []byte("fake photo bytes"), 0644); err != nil {
+		t.Fatalf("writing fake photo: %v", err)
+	}
+
+	e := &DayOneExporter{OutputDir: outputDir}
+	entry := MarkdownEntry{
+		Title:        "Day One Entry",
+		MarkdownText: "Some text.",
+		CreationDate: time.Date(2025, 5, 14, 12, 0, 0, 0, time.UTC),
+		Media:        map[string]MediaFile{photoPath: {Path: "ab/abcdef.jpg"}},
+	}
+	if err := e.WriteEntry(entry); err != nil {
+		t.Fatalf("WriteEntry: %v", err)
+	}
+	if err := e.Finalize(); err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "Journal.json"))
+	if err != nil {
+		t.Fatalf("reading Journal.json: %v", err)
+	}
+	var bundle struct {
+		Entries []dayOneEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatalf("unmarshaling Journal.json: %v", err)
+	}
+	if len(bundle.Entries) != 1 {
+		t.Fatalf("expected exactly one bundled entry, got %d", len(bundle.Entries))
+	}
+	got := bundle.Entries[0]
+	if !strings.Contains(got.Text, "Day One Entry") {
+		t.Fatalf("expected the title to be folded into text, got %q", got.Text)
+	}
+	if len(got.Photos) != 1 || got.Photos[0].MD5 == "" {
+		t.Fatalf("expected exactly one staged photo with an MD5 digest, got %+v", got.Photos)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(outputDir, "photos", "*"))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected exactly one staged photo file, found %v", matches)
+	}
+}
+
+func TestMarkdownToGemtextFlattensInlineConstructs(t *testing.T) {
+	in := "# Title\n\n![](media/ab/cd.jpg)\n\nSome **bold** and a [link](https://example.com) in a line.\n\n- bullet one"
+	out := markdownToGemtext(in)
+
+	if !strings.Contains(out, "=> media/ab/cd.jpg ab/cd.jpg") {
+		t.Fatalf("expected the image-only line to become its own => line, got:\n%s", out)
+	}
+	if strings.Contains(out, "**") {
+		t.Fatalf("expected emphasis markers to be stripped, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Some bold and a link in a line.") {
+		t.Fatalf("expected the link's label to replace it inline, got:\n%s", out)
+	}
+	if !strings.Contains(out, "=> https://example.com link") {
+		t.Fatalf("expected the link itself to be demoted to its own => line, got:\n%s", out)
+	}
+	if !strings.Contains(out, "* bullet one") {
+		t.Fatalf("expected a markdown bullet to become a gemtext bullet, got:\n%s", out)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestYAMLScalarQuotesUnsafeValues(t *testing.T) {
+	cases := []struct {
+		in       string
+		wantSafe bool
+	}{
+		{"plain", true},
+		{"", false},
+		{" leading space", false},
+		{"trailing space ", false},
+		{"colon: inside", false},
+		{"#hashtag", false},
+		{`quote"mark`, false},
+	}
+	for _, c := range cases {
+		got := yamlScalar(c.in)
+		isSafe := got == c.in
+		if isSafe != c.wantSafe {
+			t.Errorf("yamlScalar(%q) = %q, wantSafe=%v", c.in, got, c.wantSafe)
+		}
+	}
+}
+
+func TestRenderFrontMatterYAMLEscapesAndOrdersGlobals(t *testing.T) {
+	entry := MarkdownEntry{
+		Title:      "Has: a colon",
+		UUID:       "abc-123",
+		SourceFile: "entry.html",
+	}
+	globals := map[string]string{"zebra": "z", "apple": "a"}
+
+	out := renderFrontMatter(FrontMatterYAML, entry, globals)
+
+	if !strings.Contains(out, `title: "Has: a colon"`) {
+		t.Fatalf("expected title with a colon to be quoted, got:\n%s", out)
+	}
+	if i, j := strings.Index(out, "apple:"), strings.Index(out, "zebra:"); i == -1 || j == -1 || i > j {
+		t.Fatalf("expected globals in sorted key order (apple before zebra), got:\n%s", out)
+	}
+}
+
+func TestRenderFrontMatterGlobalsDontOverrideFixedFields(t *testing.T) {
+	entry := MarkdownEntry{Title: "Real Title"}
+	globals := map[string]string{"title": "Should Be Ignored"}
+
+	out := renderFrontMatter(FrontMatterYAML, entry, globals)
+
+	if strings.Contains(out, "Should Be Ignored") {
+		t.Fatalf("expected a -global named 'title' not to override the entry's own title, got:\n%s", out)
+	}
+	if !strings.Contains(out, "title: Real Title") {
+		t.Fatalf("expected the entry's own title to be present, got:\n%s", out)
+	}
+}
+
+func TestRenderFrontMatterTOMLQuotesValues(t *testing.T) {
+	entry := MarkdownEntry{
+		Title:      `Has "quotes"`,
+		UUID:       "abc-123",
+		SourceFile: "entry.html",
+		Tags:       []string{"one", "two"},
+	}
+
+	out := renderFrontMatter(FrontMatterTOML, entry, nil)
+
+	if !strings.Contains(out, `title = "Has \"quotes\""`) {
+		t.Fatalf("expected TOML title to be quote-escaped, got:\n%s", out)
+	}
+	if !strings.Contains(out, `tags = ["one", "two"]`) {
+		t.Fatalf("expected TOML tags as a quoted array, got:\n%s", out)
+	}
+}
+
+func TestRenderFrontMatterNoneIsEmpty(t *testing.T) {
+	entry := MarkdownEntry{Title: "Anything"}
+	if out := renderFrontMatter(FrontMatterNone, entry, nil); out != "" {
+		t.Fatalf("expected FrontMatterNone to render nothing, got %q", out)
+	}
+}
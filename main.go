@@ -2,18 +2,30 @@ package main
 
 import (
 	"archive/zip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/google/uuid"
+	"github.com/rwcarlsen/goexif/exif"
 )
 
 // --- Journal Data Structure ---
@@ -21,8 +33,91 @@ type MarkdownEntry struct {
 	CreationDate time.Time
 	Title        string
 	MarkdownText string
-	// Media map: original source path -> new file name in media subdir
-	Media map[string]string
+	// Media maps each original source path to where it landed in the
+	// content-addressed media store under outputDir/media.
+	Media map[string]MediaFile
+	// UUID uniquely identifies this entry across runs, independent of title or date.
+	UUID string
+	// Tags are any hashtag/label values Apple Journal rendered alongside the entry.
+	Tags []string
+	// SourceFile is the base name of the HTML file this entry was parsed from.
+	SourceFile string
+	// Meta holds optional moment/mood metadata parsed out of the entry header, if present.
+	Meta map[string]string
+	// Warnings collects any non-fatal problems noticed while parsing, for
+	// inclusion in the -manifest/-dryrun output alongside the usual log lines.
+	Warnings []string
+}
+
+// MediaFile describes a media file as stored in the content-addressed media
+// store: Path is its location relative to the output's media directory (e.g.
+// "ab/ab34...cd.jpg"), named after its own SHA256 digest.
+type MediaFile struct {
+	Path   string
+	SHA256 string
+	// CaptureTime is this file's best-known capture time: its EXIF
+	// DateTimeOriginal if present, otherwise its file modification time.
+	CaptureTime time.Time
+}
+
+// FrontMatterFormat selects how (if at all) front matter is emitted at the top
+// of an entry's output file.
+type FrontMatterFormat string
+
+const (
+	FrontMatterNone FrontMatterFormat = "none"
+	FrontMatterYAML FrontMatterFormat = "yaml"
+	FrontMatterTOML FrontMatterFormat = "toml"
+)
+
+// defaultBodyTemplate reproduces the tool's original fixed layout: optional
+// front matter followed by a title heading (omitted for untitled entries)
+// and the converted body.
+const defaultBodyTemplate = "{{.FrontMatter}}{{if .Title}}# {{.Title}}\n\n{{end}}{{.Body}}"
+
+// RenderOptions bundles the knobs that control how an entry is rendered to
+// its final output file.
+type RenderOptions struct {
+	FrontMatter FrontMatterFormat
+	Template    *template.Template
+	Globals     map[string]string
+	// OutFormat is an optional strftime-like path template (e.g.
+	// "%Y/%m/%d-%H%M%S-{title}") controlling each entry's output path,
+	// including any nested directories. Empty means the flat default,
+	// "<date>-<title>.md".
+	OutFormat string
+}
+
+// templateData is the value exposed to the configurable body template.
+type templateData struct {
+	Title       string
+	Body        string
+	Date        time.Time
+	Tags        []string
+	UUID        string
+	SourceFile  string
+	Media       []string
+	Meta        map[string]string
+	FrontMatter string
+	// Globals holds the -global key=value values, so a custom -template can
+	// reference them directly instead of parsing them back out of FrontMatter.
+	Globals map[string]string
+}
+
+// stringMapFlag implements flag.Value for repeatable "-global key=value" flags.
+type stringMapFlag map[string]string
+
+func (m stringMapFlag) String() string {
+	return fmt.Sprintf("%v", map[string]string(m))
+}
+
+func (m stringMapFlag) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("invalid -global value %q, expected key=value", s)
+	}
+	m[key] = value
+	return nil
 }
 
 // --- Global Markdown Converter ---
@@ -34,10 +129,6 @@ func init() {
 
 // --- Helper Functions ---
 
-
-
-
-
 func unzip(src, dest string) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
@@ -50,7 +141,6 @@ func unzip(src, dest string) error {
 		return err
 	}
 
-
 	for _, f := range r.File {
 		fpath := filepath.Join(dest, f.Name)
 
@@ -88,6 +178,13 @@ func unzip(src, dest string) error {
 		if err != nil {
 			return err
 		}
+
+		// Preserve the zip entry's own modification time instead of leaving
+		// it at extraction time ("now"), since mediaCaptureTime falls back
+		// to a media file's mtime when it has no EXIF capture time.
+		if err := os.Chtimes(fpath, f.Modified, f.Modified); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -118,8 +215,7 @@ func parseAppleDate(dateStr string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("failed to parse date string '%s' with known layouts: %w", dateStr, err)
 }
 
-
-func processEntryHTML(htmlFilePath string, baseResourcesPath string) (MarkdownEntry, error) {
+func processEntryHTML(htmlFilePath string, entriesRoot string, outputDir string, matcher *EntryMatcher, dryRun bool) (MarkdownEntry, error) {
 	file, err := os.Open(htmlFilePath)
 	if err != nil {
 		return MarkdownEntry{}, fmt.Errorf("opening HTML file %s: %w", htmlFilePath, err)
@@ -132,81 +228,133 @@ func processEntryHTML(htmlFilePath string, baseResourcesPath string) (MarkdownEn
 	}
 
 	entry := MarkdownEntry{
-		Media: make(map[string]string),
+		Media:      make(map[string]MediaFile),
+		UUID:       uuid.New().String(),
+		SourceFile: filepath.Base(htmlFilePath),
+	}
+
+	// warn records a non-fatal problem both in the log (as before) and on
+	// the entry itself, so it surfaces in -manifest/-dryrun output too.
+	warn := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		log.Printf("Warning: %s", msg)
+		entry.Warnings = append(entry.Warnings, msg)
 	}
 
 	// --- Extract Date ---
+	// The pageHeader only ever gives a date, not a time of day, so it's
+	// treated as a fallback: attached photos' EXIF capture times (or, failing
+	// that, their file mtimes) take priority when available. Resolved once
+	// the body/media loop below has had a chance to inspect attached photos.
 	dateStr := strings.TrimSpace(doc.Find("div.pageHeader").First().Text())
+	var headerDate time.Time
+	var headerErr error
 	if dateStr == "" {
-		return MarkdownEntry{}, fmt.Errorf("no date found in pageHeader for %s", htmlFilePath)
-	}
-	creationTime, err := parseAppleDate(dateStr)
-	if err != nil {
-		return MarkdownEntry{}, fmt.Errorf("could not parse date '%s' for %s: %w", dateStr, htmlFilePath, err)
+		headerErr = fmt.Errorf("no date found in pageHeader for %s", htmlFilePath)
+	} else {
+		headerDate, headerErr = parseAppleDate(dateStr)
 	}
-	entry.CreationDate = creationTime
+
+	// --- Extract Moment/Mood Metadata & Tags (best effort; absent on most entries) ---
+	entry.Meta = extractHeaderMeta(doc)
+	entry.Tags = extractTags(doc)
 
 	// --- Extract Title ---
-	titleSelection := doc.Find("div.title span.s2").First()
-	if titleSelection.Length() > 0 {
-		entry.Title = strings.TrimSpace(titleSelection.Text())
-	} else {
-		// Fallback for titles in different structures or use filename
-		fn := filepath.Base(htmlFilePath)
-		fn = strings.TrimSuffix(fn, filepath.Ext(fn))
-		parts := strings.SplitN(fn, "_", 2)
-		if len(parts) > 1 && strings.Contains(parts[0], "-") {
-			entry.Title = strings.ReplaceAll(parts[1], "_", " ")
-		}
-	}
+	entry.Title = extractTitle(doc, htmlFilePath)
 
 	// --- Extract Body Content & Media ---
 	var bodyMarkdownBuilder strings.Builder
+	// earliestEXIFCapture and earliestMTimeCapture are tracked separately:
+	// only an EXIF timestamp is trustworthy enough to override a
+	// successfully parsed pageHeader date (see mediaCaptureTime).
+	var earliestEXIFCapture time.Time
+	var earliestMTimeCapture time.Time
 	doc.Find("div.pageContainer").Children().Each(func(i int, s *goquery.Selection) {
 		if s.Is("div.pageHeader, div.title") {
 			return // Skip header and title as they are already processed
 		}
 
 		if s.Is("div.assetGrid") {
-			s.Find("div.gridItem.assetType_photo img.asset_image").Each(func(j int, imgSel *goquery.Selection) {
-				imgSrc, exists := imgSel.Attr("src")
+			s.Find("div.gridItem.assetType_photo img.asset_image, div.gridItem.assetType_video video source, div.gridItem.assetType_video video, div.gridItem.assetType_audio audio source, div.gridItem.assetType_audio audio").Each(func(j int, assetSel *goquery.Selection) {
+				assetSrc, exists := assetSel.Attr("src")
 				if !exists {
 					return
 				}
 
-				originalImageName := filepath.Base(imgSrc)
-				newImageName := fmt.Sprintf("%s-%s", uuid.New().String(), originalImageName)
-				absImgSrc := filepath.Clean(filepath.Join(filepath.Dir(htmlFilePath), imgSrc))
+				absAssetSrc := filepath.Clean(filepath.Join(filepath.Dir(htmlFilePath), assetSrc))
 
-				if _, err := os.Stat(absImgSrc); os.IsNotExist(err) {
-					log.Printf("Warning: Image file not found: %s (referenced in %s)", absImgSrc, htmlFilePath)
+				// ExcludesMedia matches against the same entries-root-relative
+				// namespace AllowEntry uses, not the raw (entry-relative) src
+				// attribute, so a predicate like "-exclude Entries/*/media.jpg"
+				// behaves consistently for entries and their media alike.
+				relAssetSrc := absAssetSrc
+				if rel, err := filepath.Rel(entriesRoot, absAssetSrc); err == nil {
+					relAssetSrc = rel
+				}
+				if matcher.ExcludesMedia(relAssetSrc) {
 					return
 				}
 
-				entry.Media[absImgSrc] = newImageName
-				bodyMarkdownBuilder.WriteString(fmt.Sprintf("![](media/%s)\n\n", newImageName))
+				if _, err := os.Stat(absAssetSrc); os.IsNotExist(err) {
+					warn("Media file not found: %s (referenced in %s)", absAssetSrc, htmlFilePath)
+					return
+				}
+
+				mediaFile, err := storeMedia(absAssetSrc, outputDir, dryRun)
+				if err != nil {
+					warn("Failed to store media file %s: %v", absAssetSrc, err)
+					return
+				}
+
+				if captureTime, fromEXIF, ok := mediaCaptureTime(absAssetSrc); ok {
+					mediaFile.CaptureTime = captureTime
+					if fromEXIF {
+						if earliestEXIFCapture.IsZero() || captureTime.Before(earliestEXIFCapture) {
+							earliestEXIFCapture = captureTime
+						}
+					} else if earliestMTimeCapture.IsZero() || captureTime.Before(earliestMTimeCapture) {
+						earliestMTimeCapture = captureTime
+					}
+				}
+
+				entry.Media[absAssetSrc] = mediaFile
+				bodyMarkdownBuilder.WriteString(fmt.Sprintf("![](media/%s)\n\n", mediaFile.Path))
 			})
 		} else {
 			htmlContent, err := goquery.OuterHtml(s)
 			if err != nil {
-				log.Printf("Warning: Could not get HTML content for a section in %s: %v", htmlFilePath, err)
+				warn("Could not get HTML content for a section in %s: %v", htmlFilePath, err)
 				return
 			}
 
 			markdownFrag, err := markdownConverter.ConvertString(htmlContent)
 			if err != nil {
-				log.Printf("Warning: Markdown conversion error for a fragment in %s: %v", htmlFilePath, err)
+				warn("Markdown conversion error for a fragment in %s: %v", htmlFilePath, err)
 			} else {
 				bodyMarkdownBuilder.WriteString(strings.TrimSpace(markdownFrag) + "\n\n")
 			}
 		}
 	})
 
-	finalMarkdown := strings.TrimSpace(bodyMarkdownBuilder.String())
-	if entry.Title != "" {
-		entry.MarkdownText = fmt.Sprintf("# %s\n\n%s", entry.Title, finalMarkdown)
-	} else {
-		entry.MarkdownText = finalMarkdown
+	entry.MarkdownText = strings.TrimSpace(bodyMarkdownBuilder.String())
+
+	// A successfully parsed header date only loses to an EXIF capture time,
+	// which is the only fallback precise/reliable enough to be worth
+	// overriding it with; a bare file mtime is merely a last resort for
+	// entries the header itself couldn't date.
+	switch {
+	case !earliestEXIFCapture.IsZero():
+		entry.CreationDate = earliestEXIFCapture
+	case headerErr == nil:
+		entry.CreationDate = headerDate
+	case !earliestMTimeCapture.IsZero():
+		entry.CreationDate = earliestMTimeCapture
+	default:
+		if info, err := os.Stat(htmlFilePath); err == nil {
+			entry.CreationDate = info.ModTime()
+		} else {
+			return MarkdownEntry{}, fmt.Errorf("could not determine creation date for %s: %w", htmlFilePath, headerErr)
+		}
 	}
 
 	if entry.MarkdownText == "" && len(entry.Media) == 0 {
@@ -216,63 +364,1218 @@ func processEntryHTML(htmlFilePath string, baseResourcesPath string) (MarkdownEn
 	return entry, nil
 }
 
-
-func saveMarkdownFile(outputDir string, entry MarkdownEntry) error {
-	// Create media subdirectory if it doesn't exist
-	mediaDir := filepath.Join(outputDir, "media")
-	if err := os.MkdirAll(mediaDir, 0755); err != nil {
-		return fmt.Errorf("creating media directory %s: %w", mediaDir, err)
+// extractHeaderMeta pulls Apple Journal's optional moment/mood metadata out of
+// the entry header, if present, for inclusion in front matter.
+func extractHeaderMeta(doc *goquery.Document) map[string]string {
+	meta := make(map[string]string)
+	if moment := strings.TrimSpace(doc.Find(".momentInfo").First().Text()); moment != "" {
+		meta["moment"] = moment
 	}
+	if mood := strings.TrimSpace(doc.Find(".moodInfo, .mood").First().Text()); mood != "" {
+		meta["mood"] = mood
+	}
+	return meta
+}
 
-	// Copy media files
-	for src, newName := range entry.Media {
-		dst := filepath.Join(mediaDir, newName)
-		if err := copyFile(src, dst); err != nil {
-			log.Printf("Warning: Failed to copy media file from %s to %s: %v", src, dst, err)
-			// Continue trying to save the rest of the entry
+// extractTags collects any hashtag/label values Apple Journal rendered
+// alongside the entry.
+func extractTags(doc *goquery.Document) []string {
+	var tags []string
+	doc.Find(".tag, .hashtag").Each(func(_ int, s *goquery.Selection) {
+		if tag := strings.TrimSpace(s.Text()); tag != "" {
+			tags = append(tags, tag)
 		}
+	})
+	return tags
+}
+
+// extractTitle returns the entry's title, preferring the rendered title
+// span Apple Journal emits and falling back to the trailing portion of the
+// HTML filename (exported entries are named "<date>-<uuid>_<title>.html").
+func extractTitle(doc *goquery.Document, htmlFilePath string) string {
+	titleSelection := doc.Find("div.title span.s2").First()
+	if titleSelection.Length() > 0 {
+		return strings.TrimSpace(titleSelection.Text())
 	}
+	fn := filepath.Base(htmlFilePath)
+	fn = strings.TrimSuffix(fn, filepath.Ext(fn))
+	parts := strings.SplitN(fn, "_", 2)
+	if len(parts) > 1 && strings.Contains(parts[0], "-") {
+		return strings.ReplaceAll(parts[1], "_", " ")
+	}
+	return ""
+}
 
-	// Sanitize title for file name
-	safeTitle := strings.ReplaceAll(entry.Title, "/", "-")
-	safeTitle = strings.ReplaceAll(safeTitle, "\"", "'")
-	// Further sanitization can be added here
+// saveMarkdownFile renders entry to its final Markdown file and returns the
+// path it was written to, relative to outputDir.
+func saveMarkdownFile(outputDir string, entry MarkdownEntry, opts RenderOptions) (string, error) {
+	// Media files were already copied into the content-addressed store by
+	// processEntryHTML; nothing left to do with entry.Media here but render it.
+	content, err := renderEntry(entry, opts)
+	if err != nil {
+		return "", fmt.Errorf("rendering entry %q: %w", entry.Title, err)
+	}
 
 	// Create markdown file
-	datePrefix := entry.CreationDate.Format("2006-01-02")
-	fileName := fmt.Sprintf("%s-%s.md", datePrefix, safeTitle)
-	filePath := filepath.Join(outputDir, fileName)
+	relPath := plannedMarkdownPath(entry, opts.OutFormat)
+	filePath := filepath.Join(outputDir, relPath)
 
-	err := os.WriteFile(filePath, []byte(entry.MarkdownText), 0644)
-	if err != nil {
-		return fmt.Errorf("writing markdown file %s: %w", filePath, err)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", fmt.Errorf("creating output directory for %s: %w", filePath, err)
+	}
+
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("writing markdown file %s: %w", filePath, err)
 	}
 
 	log.Printf("Successfully saved entry to %s", filePath)
+	return relPath, nil
+}
+
+// renderEntry executes the configured body template over entry, with any
+// front matter pre-rendered and made available as the FrontMatter field.
+func renderEntry(entry MarkdownEntry, opts RenderOptions) (string, error) {
+	mediaList := make([]string, 0, len(entry.Media))
+	for _, mf := range entry.Media {
+		mediaList = append(mediaList, "media/"+mf.Path)
+	}
+	sort.Strings(mediaList)
+
+	data := templateData{
+		Title:       entry.Title,
+		Body:        entry.MarkdownText,
+		Date:        entry.CreationDate,
+		Tags:        entry.Tags,
+		UUID:        entry.UUID,
+		SourceFile:  entry.SourceFile,
+		Media:       mediaList,
+		Meta:        entry.Meta,
+		FrontMatter: renderFrontMatter(opts.FrontMatter, entry, opts.Globals),
+		Globals:     opts.Globals,
+	}
+
+	var b strings.Builder
+	if err := opts.Template.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// loadTemplate parses the body template from path, or falls back to
+// defaultBodyTemplate when path is empty.
+func loadTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return template.New("entry").Parse(defaultBodyTemplate)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading template file %s: %w", path, err)
+	}
+	return template.New(filepath.Base(path)).Parse(string(contents))
+}
+
+// strftimeReplacer translates the subset of strftime directives outputPath
+// supports into Go's reference-time layout.
+var strftimeReplacer = strings.NewReplacer(
+	"%Y", "2006",
+	"%m", "01",
+	"%d", "02",
+	"%H", "15",
+	"%M", "04",
+	"%S", "05",
+)
+
+// outputPath returns entry's output path, relative to the output directory.
+// With no outFormat, it's the flat "<date>-<title>.md" layout. Otherwise
+// outFormat is a strftime-like template (e.g. "%Y/%m/%d-%H%M%S-{title}"):
+// its directives are translated to Go's reference-time layout and expanded
+// against entry.CreationDate, then "{title}" is substituted with safeTitle,
+// any path separators in the template producing nested directories.
+func outputPath(entry MarkdownEntry, outFormat string, safeTitle string) string {
+	if outFormat == "" {
+		return fmt.Sprintf("%s-%s.md", entry.CreationDate.Format("2006-01-02"), safeTitle)
+	}
+	expanded := entry.CreationDate.Format(strftimeReplacer.Replace(outFormat))
+	expanded = strings.ReplaceAll(expanded, "{title}", safeTitle)
+	return expanded + ".md"
+}
+
+// plannedMarkdownPath computes the relative path saveMarkdownFile will write
+// entry to under outFormat, without performing any I/O, so it can also back
+// the -dryrun/-manifest "planned output path" report.
+func plannedMarkdownPath(entry MarkdownEntry, outFormat string) string {
+	safeTitle := strings.ReplaceAll(entry.Title, "/", "-")
+	safeTitle = strings.ReplaceAll(safeTitle, "\"", "'")
+	return outputPath(entry, outFormat, safeTitle)
+}
+
+// frontMatterField is a single ordered key/value pair destined for front
+// matter; value is a string, a []string, or a []mediaFrontMatterEntry.
+type frontMatterField struct {
+	key   string
+	value interface{}
+}
+
+// mediaFrontMatterEntry records one media file's path and sha256 digest for
+// inclusion in an entry's front matter, so downstream tools can verify
+// integrity without rehashing.
+type mediaFrontMatterEntry struct {
+	Path   string
+	SHA256 string
+}
+
+// frontMatterFields assembles the front matter fields for entry: the fixed
+// date/title/uuid/source-file/tags/media fields, followed by any moment/mood
+// metadata parsed from the HTML, followed by user-supplied globals that
+// don't collide with an already-set key.
+func frontMatterFields(entry MarkdownEntry, globals map[string]string) []frontMatterField {
+	fields := []frontMatterField{
+		{"date", entry.CreationDate.Format("2006-01-02")},
+		{"title", entry.Title},
+		{"uuid", entry.UUID},
+		{"source-file", entry.SourceFile},
+	}
+
+	if len(entry.Tags) > 0 {
+		fields = append(fields, frontMatterField{"tags", entry.Tags})
+	}
+
+	if len(entry.Media) > 0 {
+		media := make([]mediaFrontMatterEntry, 0, len(entry.Media))
+		for _, mf := range entry.Media {
+			media = append(media, mediaFrontMatterEntry{Path: "media/" + mf.Path, SHA256: mf.SHA256})
+		}
+		sort.Slice(media, func(i, j int) bool { return media[i].Path < media[j].Path })
+		fields = append(fields, frontMatterField{"media", media})
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		seen[f.key] = true
+	}
+
+	metaKeys := make([]string, 0, len(entry.Meta))
+	for k := range entry.Meta {
+		metaKeys = append(metaKeys, k)
+	}
+	sort.Strings(metaKeys)
+	for _, k := range metaKeys {
+		fields = append(fields, frontMatterField{k, entry.Meta[k]})
+		seen[k] = true
+	}
+
+	globalKeys := make([]string, 0, len(globals))
+	for k := range globals {
+		globalKeys = append(globalKeys, k)
+	}
+	sort.Strings(globalKeys)
+	for _, k := range globalKeys {
+		if seen[k] {
+			continue
+		}
+		fields = append(fields, frontMatterField{k, globals[k]})
+	}
+
+	return fields
+}
+
+// renderFrontMatter serializes entry's front matter fields in the requested
+// format, returning "" for FrontMatterNone.
+func renderFrontMatter(format FrontMatterFormat, entry MarkdownEntry, globals map[string]string) string {
+	if format == FrontMatterNone {
+		return ""
+	}
+
+	fields := frontMatterFields(entry, globals)
+
+	var b strings.Builder
+	switch format {
+	case FrontMatterYAML:
+		b.WriteString("---\n")
+		for _, f := range fields {
+			writeYAMLField(&b, f.key, f.value)
+		}
+		b.WriteString("---\n\n")
+	case FrontMatterTOML:
+		b.WriteString("+++\n")
+		var mediaTables []frontMatterField
+		for _, f := range fields {
+			if _, ok := f.value.([]mediaFrontMatterEntry); ok {
+				mediaTables = append(mediaTables, f)
+				continue
+			}
+			writeTOMLField(&b, f.key, f.value)
+		}
+		// TOML arrays of tables ([[media]]) can't be nested under "key = value"
+		// lines, so they're appended as their own blocks after the scalar fields.
+		for _, f := range mediaTables {
+			for _, m := range f.value.([]mediaFrontMatterEntry) {
+				fmt.Fprintf(&b, "[[%s]]\n", f.key)
+				fmt.Fprintf(&b, "path = %s\n", strconv.Quote(m.Path))
+				fmt.Fprintf(&b, "sha256 = %s\n", strconv.Quote(m.SHA256))
+			}
+		}
+		b.WriteString("+++\n\n")
+	}
+	return b.String()
+}
+
+func writeYAMLField(b *strings.Builder, key string, value interface{}) {
+	switch v := value.(type) {
+	case []string:
+		if len(v) == 0 {
+			fmt.Fprintf(b, "%s: []\n", key)
+			return
+		}
+		fmt.Fprintf(b, "%s:\n", key)
+		for _, item := range v {
+			fmt.Fprintf(b, "  - %s\n", yamlScalar(item))
+		}
+	case []mediaFrontMatterEntry:
+		if len(v) == 0 {
+			fmt.Fprintf(b, "%s: []\n", key)
+			return
+		}
+		fmt.Fprintf(b, "%s:\n", key)
+		for _, m := range v {
+			fmt.Fprintf(b, "  - path: %s\n    sha256: %s\n", yamlScalar(m.Path), yamlScalar(m.SHA256))
+		}
+	default:
+		fmt.Fprintf(b, "%s: %s\n", key, yamlScalar(fmt.Sprintf("%v", v)))
+	}
+}
+
+func writeTOMLField(b *strings.Builder, key string, value interface{}) {
+	switch v := value.(type) {
+	case []string:
+		quoted := make([]string, len(v))
+		for i, item := range v {
+			quoted[i] = strconv.Quote(item)
+		}
+		fmt.Fprintf(b, "%s = [%s]\n", key, strings.Join(quoted, ", "))
+	default:
+		fmt.Fprintf(b, "%s = %s\n", key, strconv.Quote(fmt.Sprintf("%v", v)))
+	}
+}
+
+// yamlScalar quotes s if it contains characters that are unsafe to emit as a
+// bare YAML scalar.
+func yamlScalar(s string) string {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, ":#{}[]&*!|>'\"%@`") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// shardDirCount is the number of two-hex-digit shard directories
+// (00 through ff) precreated under the media store.
+const shardDirCount = 256
+
+// shardLocks serializes the check-then-rename in storeMedia per shard, so
+// concurrent Parse workers racing to store files into the same shard can't
+// both see a slot as empty and rename into it at once.
+var shardLocks [shardDirCount]sync.Mutex
+
+// prepOutput precreates outputDir/media and its 256 shard directories up
+// front, so storing individual media files never pays for a MkdirAll call.
+func prepOutput(outputDir string) error {
+	mediaDir := filepath.Join(outputDir, "media")
+	for i := 0; i < shardDirCount; i++ {
+		shard := fmt.Sprintf("%02x", i)
+		if err := os.MkdirAll(filepath.Join(mediaDir, shard), 0755); err != nil {
+			return fmt.Errorf("creating media shard directory %s: %w", shard, err)
+		}
+	}
 	return nil
 }
 
-func copyFile(src, dst string) error {
+// storeMedia copies src into outputDir's content-addressed media store,
+// sharded by the first two hex characters of its SHA256 digest. It streams
+// the file once, hashing while copying to a temp file, then renames into the
+// final shard slot only if that digest isn't already present, so the same
+// photo referenced by many entries is stored exactly once on disk. When
+// dryRun is true, src is hashed but never copied or renamed, so the returned
+// MediaFile reports exactly where it would have landed.
+func storeMedia(src, outputDir string, dryRun bool) (MediaFile, error) {
 	in, err := os.Open(src)
 	if err != nil {
-		return err
+		return MediaFile{}, err
 	}
 	defer in.Close()
 
-	out, err := os.Create(dst)
+	mediaDir := filepath.Join(outputDir, "media")
+
+	if dryRun {
+		hasher := sha256.New()
+		if _, err := io.Copy(hasher, in); err != nil {
+			return MediaFile{}, err
+		}
+		digest := hex.EncodeToString(hasher.Sum(nil))
+		shard := digest[:2]
+		relPath := filepath.ToSlash(filepath.Join(shard, digest+strings.ToLower(filepath.Ext(src))))
+		return MediaFile{Path: relPath, SHA256: digest}, nil
+	}
+
+	tmp, err := os.CreateTemp(mediaDir, "incoming-*")
 	if err != nil {
-		return err
+		return MediaFile{}, err
 	}
-	defer out.Close()
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once the rename below succeeds
 
-	_, err = io.Copy(out, in)
+	hasher := sha256.New()
+	if _, err := io.Copy(tmp, io.TeeReader(in, hasher)); err != nil {
+		tmp.Close()
+		return MediaFile{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return MediaFile{}, err
+	}
+
+	digest := hex.EncodeToString(hasher.Sum(nil))
+	shardBytes, err := hex.DecodeString(digest[:2])
+	if err != nil {
+		return MediaFile{}, fmt.Errorf("decoding shard for %s: %w", src, err)
+	}
+	shard := digest[:2]
+	relPath := filepath.ToSlash(filepath.Join(shard, digest+strings.ToLower(filepath.Ext(src))))
+	finalPath := filepath.Join(mediaDir, relPath)
+
+	shardLocks[shardBytes[0]].Lock()
+	defer shardLocks[shardBytes[0]].Unlock()
+
+	if _, err := os.Stat(finalPath); err == nil {
+		return MediaFile{Path: relPath, SHA256: digest}, nil
+	}
+
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return MediaFile{}, fmt.Errorf("storing media file %s: %w", src, err)
+	}
+	return MediaFile{Path: relPath, SHA256: digest}, nil
+}
+
+// mediaCaptureTime returns the best available capture time for the media
+// file at path: its EXIF DateTimeOriginal if present, otherwise its file
+// modification time. fromEXIF reports which of the two it is, since only an
+// EXIF timestamp is trustworthy enough to override a successfully parsed
+// pageHeader date (a file's mtime is, at best, when Apple Journal's export
+// extracted it). ok is false only if neither is available.
+func mediaCaptureTime(path string) (t time.Time, fromEXIF bool, ok bool) {
+	if f, err := os.Open(path); err == nil {
+		x, decodeErr := exif.Decode(f)
+		f.Close()
+		if decodeErr == nil {
+			if t, err := x.DateTime(); err == nil {
+				return t, true, true
+			}
+		}
+	}
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime(), false, true
+	}
+	return time.Time{}, false, false
+}
+
+// --- Exporters ---
+//
+// An Exporter turns parsed entries into some output destination and format.
+// WriteEntry is called once per entry, potentially from several Write
+// workers concurrently, so implementations that accumulate state (an index,
+// a bundle) must guard it themselves. Finalize is called once after every
+// entry has been written, for formats that need a closing pass.
+type Exporter interface {
+	WriteEntry(entry MarkdownEntry) error
+	Finalize() error
+}
+
+// ManifestPlanner is implemented by exporters that can report the output
+// path an entry would land at without writing anything, so -dryrun and
+// -manifest can describe a run's planned actions using the same logic
+// WriteEntry itself would use.
+type ManifestPlanner interface {
+	PlannedPath(entry MarkdownEntry) string
+}
+
+// MarkdownExporter writes each entry as a standalone .md file via
+// saveMarkdownFile, using the configured front matter and template. This is
+// the tool's original, default output format.
+type MarkdownExporter struct {
+	OutputDir string
+	Render    RenderOptions
+}
+
+func (e *MarkdownExporter) WriteEntry(entry MarkdownEntry) error {
+	_, err := saveMarkdownFile(e.OutputDir, entry, e.Render)
 	return err
 }
 
+func (e *MarkdownExporter) Finalize() error { return nil }
+
+func (e *MarkdownExporter) PlannedPath(entry MarkdownEntry) string {
+	return plannedMarkdownPath(entry, e.Render.OutFormat)
+}
+
+// markdownImageRef matches the "![](media/<path>)" syntax processEntryHTML
+// emits for attached photos.
+var markdownImageRef = regexp.MustCompile(`!\[\]\(media/([^)]+)\)`)
+
+// ObsidianExporter writes an Obsidian-flavored vault: each entry as a .md
+// file with wiki-link image embeds instead of plain Markdown images, plus a
+// _index.md MOC (map of content) linking every entry once writing is done.
+type ObsidianExporter struct {
+	OutputDir string
+	Render    RenderOptions
+
+	mu      sync.Mutex
+	indexed []obsidianIndexEntry
+}
+
+type obsidianIndexEntry struct {
+	Title string
+	Link  string // wiki-link target: the entry's output path without ".md"
+}
+
+func (e *ObsidianExporter) WriteEntry(entry MarkdownEntry) error {
+	entry.MarkdownText = markdownImageRef.ReplaceAllString(entry.MarkdownText, "![[media/$1]]")
+
+	relPath, err := saveMarkdownFile(e.OutputDir, entry, e.Render)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.indexed = append(e.indexed, obsidianIndexEntry{
+		Title: entry.Title,
+		Link:  strings.TrimSuffix(filepath.ToSlash(relPath), ".md"),
+	})
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *ObsidianExporter) PlannedPath(entry MarkdownEntry) string {
+	return plannedMarkdownPath(entry, e.Render.OutFormat)
+}
+
+func (e *ObsidianExporter) Finalize() error {
+	e.mu.Lock()
+	entries := append([]obsidianIndexEntry(nil), e.indexed...)
+	e.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Link < entries[j].Link })
+
+	var b strings.Builder
+	b.WriteString("# Journal Index\n\n")
+	for _, en := range entries {
+		title := en.Title
+		if title == "" {
+			title = en.Link
+		}
+		fmt.Fprintf(&b, "- [[%s|%s]]\n", en.Link, title)
+	}
+
+	return os.WriteFile(filepath.Join(e.OutputDir, "_index.md"), []byte(b.String()), 0644)
+}
+
+// DayOneExporter bundles every entry into a single DayOne-compatible
+// Journal.json, with photos copied into a photos/ folder keyed by their MD5
+// digest, matching DayOne's own documented export schema.
+type DayOneExporter struct {
+	OutputDir string
+
+	mu      sync.Mutex
+	entries []dayOneEntry
+}
+
+type dayOneEntry struct {
+	CreationDate string        `json:"creationDate"`
+	Text         string        `json:"text"`
+	Photos       []dayOnePhoto `json:"photos,omitempty"`
+}
+
+type dayOnePhoto struct {
+	MD5 string `json:"md5"`
+}
+
+func (e *DayOneExporter) WriteEntry(entry MarkdownEntry) error {
+	photosDir := filepath.Join(e.OutputDir, "photos")
+	if err := os.MkdirAll(photosDir, 0755); err != nil {
+		return fmt.Errorf("creating photos directory %s: %w", photosDir, err)
+	}
+
+	photos := make([]dayOnePhoto, 0, len(entry.Media))
+	for _, mf := range entry.Media {
+		storedPath := filepath.Join(e.OutputDir, "media", filepath.FromSlash(mf.Path))
+		digest, err := copyToMD5Store(storedPath, photosDir)
+		if err != nil {
+			log.Printf("Warning: Failed to stage photo %s for DayOne export: %v", storedPath, err)
+			continue
+		}
+		photos = append(photos, dayOnePhoto{MD5: digest})
+	}
+	sort.Slice(photos, func(i, j int) bool { return photos[i].MD5 < photos[j].MD5 })
+
+	text := entry.MarkdownText
+	if entry.Title != "" {
+		text = fmt.Sprintf("# %s\n\n%s", entry.Title, text)
+	}
+
+	e.mu.Lock()
+	e.entries = append(e.entries, dayOneEntry{
+		CreationDate: entry.CreationDate.UTC().Format(time.RFC3339),
+		Text:         text,
+		Photos:       photos,
+	})
+	e.mu.Unlock()
+	return nil
+}
+
+// PlannedPath reports Journal.json, the single bundle file every entry is
+// written into, since DayOne's export format has no per-entry file.
+func (e *DayOneExporter) PlannedPath(entry MarkdownEntry) string {
+	return "Journal.json"
+}
+
+func (e *DayOneExporter) Finalize() error {
+	e.mu.Lock()
+	entries := append([]dayOneEntry(nil), e.entries...)
+	e.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreationDate < entries[j].CreationDate })
+
+	bundle := struct {
+		Entries []dayOneEntry `json:"entries"`
+	}{Entries: entries}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling DayOne journal: %w", err)
+	}
+	return os.WriteFile(filepath.Join(e.OutputDir, "Journal.json"), data, 0644)
+}
+
+// copyToMD5Store copies src into dstDir, naming it after its MD5 digest as
+// DayOne's own exports do, and returns that hex digest. A file already
+// present under that digest is left alone.
+func copyToMD5Store(src, dstDir string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(dstDir, "incoming-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once the rename below succeeds
+
+	hasher := md5.New()
+	if _, err := io.Copy(tmp, io.TeeReader(in, hasher)); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	digest := hex.EncodeToString(hasher.Sum(nil))
+	finalPath := filepath.Join(dstDir, digest+strings.ToLower(filepath.Ext(src)))
+	if _, err := os.Stat(finalPath); err == nil {
+		return digest, nil
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// GmiExporter translates each entry to Gemini text (gemtext), taking cues
+// from the kiln gmi-to-HTML converter but inverted: headings already line up
+// with gemtext's own heading syntax, and markdown's inline-only constructs
+// (images, links, emphasis) are flattened into their own "=> target label"
+// lines or stripped outright, since gemtext has no inline formatting.
+type GmiExporter struct {
+	OutputDir string
+}
+
+func (e *GmiExporter) WriteEntry(entry MarkdownEntry) error {
+	var b strings.Builder
+	if entry.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", entry.Title)
+	}
+	b.WriteString(markdownToGemtext(entry.MarkdownText))
+
+	return os.WriteFile(filepath.Join(e.OutputDir, e.PlannedPath(entry)), []byte(b.String()), 0644)
+}
+
+func (e *GmiExporter) PlannedPath(entry MarkdownEntry) string {
+	safeTitle := strings.ReplaceAll(entry.Title, "/", "-")
+	safeTitle = strings.ReplaceAll(safeTitle, "\"", "'")
+	return fmt.Sprintf("%s-%s.gmi", entry.CreationDate.Format("2006-01-02"), safeTitle)
+}
+
+func (e *GmiExporter) Finalize() error { return nil }
+
+var (
+	gmiImageRef = regexp.MustCompile(`!\[\]\(media/([^)]+)\)`)
+	gmiLinkRef  = regexp.MustCompile(`\[([^\]]*)\]\(([^)]+)\)`)
+	gmiEmphasis = regexp.MustCompile("[*_`]+")
+)
+
+// markdownToGemtext flattens the markdown processEntryHTML produces into
+// gemtext, line by line: an image reference that is a line on its own
+// becomes a "=> target label" line directly, inline links are replaced by
+// their label text with the link demoted to its own "=>" line immediately
+// after (gemtext has no inline links), emphasis markers are stripped since
+// gemtext has no inline formatting, and headings/bullets/plain text pass
+// through unchanged since their syntax already matches.
+func markdownToGemtext(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if gmiImageRef.MatchString(strings.TrimSpace(line)) {
+			out = append(out, gmiImageRef.ReplaceAllString(strings.TrimSpace(line), "=> media/$1 $1"))
+			continue
+		}
+
+		var linkLines []string
+		line = gmiLinkRef.ReplaceAllStringFunc(line, func(match string) string {
+			parts := gmiLinkRef.FindStringSubmatch(match)
+			label, target := parts[1], parts[2]
+			if label == "" {
+				label = target
+			}
+			linkLines = append(linkLines, fmt.Sprintf("=> %s %s", target, label))
+			return label
+		})
+		line = gmiEmphasis.ReplaceAllString(line, "")
+		if strings.HasPrefix(line, "- ") {
+			line = "* " + strings.TrimPrefix(line, "- ")
+		}
+		out = append(out, line)
+		out = append(out, linkLines...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// --- Entry Selection (.journalignore, -include/-exclude) ---
+//
+// EntryMatcher decides which HTML entries Source hands to the pipeline and
+// which media files processEntryHTML copies, combining a gitignore-style
+// .journalignore file with repeatable -include/-exclude predicates. A
+// predicate is either a path glob or one of "before:YYYY-MM-DD",
+// "after:YYYY-MM-DD", "title:regex", or "tag:regex".
+
+// entryHeader is the lightweight subset of an entry's content predicates
+// need, peeked from the HTML before the full, more expensive
+// processEntryHTML parse runs.
+type entryHeader struct {
+	Date  time.Time
+	Title string
+	Tags  []string
+}
+
+// peekEntryHeader opens and parses just enough of htmlFilePath to evaluate
+// date/title/tag predicates against it.
+func peekEntryHeader(htmlFilePath string) (entryHeader, error) {
+	file, err := os.Open(htmlFilePath)
+	if err != nil {
+		return entryHeader{}, err
+	}
+	defer file.Close()
+
+	doc, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		return entryHeader{}, err
+	}
+
+	var h entryHeader
+	if dateStr := strings.TrimSpace(doc.Find("div.pageHeader").First().Text()); dateStr != "" {
+		if d, err := parseAppleDate(dateStr); err == nil {
+			h.Date = d
+		}
+	}
+	h.Title = extractTitle(doc, htmlFilePath)
+	h.Tags = extractTags(doc)
+	return h, nil
+}
+
+// entryPredicate is one -include/-exclude value: either a path glob, or a
+// date/title/tag predicate that requires peeking at the entry's content.
+type entryPredicate struct {
+	kind string // "glob", "before", "after", "title", "tag"
+	glob string
+	date time.Time
+	re   *regexp.Regexp
+}
+
+func parsePredicate(raw string) (entryPredicate, error) {
+	switch {
+	case strings.HasPrefix(raw, "before:"):
+		d, err := time.Parse("2006-01-02", strings.TrimPrefix(raw, "before:"))
+		if err != nil {
+			return entryPredicate{}, fmt.Errorf("invalid before: date %q: %w", raw, err)
+		}
+		return entryPredicate{kind: "before", date: d}, nil
+	case strings.HasPrefix(raw, "after:"):
+		d, err := time.Parse("2006-01-02", strings.TrimPrefix(raw, "after:"))
+		if err != nil {
+			return entryPredicate{}, fmt.Errorf("invalid after: date %q: %w", raw, err)
+		}
+		return entryPredicate{kind: "after", date: d}, nil
+	case strings.HasPrefix(raw, "title:"):
+		re, err := regexp.Compile(strings.TrimPrefix(raw, "title:"))
+		if err != nil {
+			return entryPredicate{}, fmt.Errorf("invalid title: regex %q: %w", raw, err)
+		}
+		return entryPredicate{kind: "title", re: re}, nil
+	case strings.HasPrefix(raw, "tag:"):
+		re, err := regexp.Compile(strings.TrimPrefix(raw, "tag:"))
+		if err != nil {
+			return entryPredicate{}, fmt.Errorf("invalid tag: regex %q: %w", raw, err)
+		}
+		return entryPredicate{kind: "tag", re: re}, nil
+	default:
+		return entryPredicate{kind: "glob", glob: raw}, nil
+	}
+}
+
+// matches reports whether predicate p applies to the entry at relPath (for
+// glob matching) / absPath (for opening the file), peeking its header
+// lazily (and only once) the first time a date/title/tag predicate needs
+// it.
+func (p entryPredicate) matches(relPath, absPath string, header *entryHeader, headerErr *error, peeked *bool) bool {
+	loadHeader := func() bool {
+		if !*peeked {
+			h, err := peekEntryHeader(absPath)
+			*header, *headerErr, *peeked = h, err, true
+		}
+		return *headerErr == nil
+	}
+
+	switch p.kind {
+	case "glob":
+		if ok, _ := filepath.Match(p.glob, relPath); ok {
+			return true
+		}
+		ok, _ := filepath.Match(p.glob, filepath.Base(relPath))
+		return ok
+	case "before":
+		return loadHeader() && !header.Date.IsZero() && header.Date.Before(p.date)
+	case "after":
+		return loadHeader() && !header.Date.IsZero() && header.Date.After(p.date)
+	case "title":
+		return loadHeader() && p.re.MatchString(header.Title)
+	case "tag":
+		if !loadHeader() {
+			return false
+		}
+		for _, t := range header.Tags {
+			if p.re.MatchString(t) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// ignoreRule is one non-blank, non-comment line of a .journalignore file.
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	anchored bool
+}
+
+// ignoreMatcher applies a .journalignore file's rules in file order, the
+// same precedence .gitignore uses: later rules, including negations,
+// override earlier ones.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// loadJournalIgnore reads root/.journalignore, returning a nil matcher (which
+// matches nothing) if the file doesn't exist.
+func loadJournalIgnore(root string) (*ignoreMatcher, error) {
+	data, err := os.ReadFile(filepath.Join(root, ".journalignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading .journalignore: %w", err)
+	}
+
+	m := &ignoreMatcher{}
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		rule := ignoreRule{pattern: trimmed}
+		if strings.HasPrefix(rule.pattern, "!") {
+			rule.negate = true
+			rule.pattern = rule.pattern[1:]
+		}
+		if strings.HasPrefix(rule.pattern, "/") {
+			rule.anchored = true
+			rule.pattern = strings.TrimPrefix(rule.pattern, "/")
+		}
+		rule.pattern = strings.TrimSuffix(rule.pattern, "/")
+		m.rules = append(m.rules, rule)
+	}
+	return m, nil
+}
+
+// Match reports whether relPath is ignored.
+func (m *ignoreMatcher) Match(relPath string) bool {
+	if m == nil {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+	ignored := false
+	for _, r := range m.rules {
+		matched, _ := filepath.Match(r.pattern, relPath)
+		if !matched && !r.anchored {
+			matched, _ = filepath.Match(r.pattern, filepath.Base(relPath))
+		}
+		if matched {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// EntryMatcher is the combined .journalignore + -include/-exclude filter
+// threaded through Source (for entries) and processEntryHTML (for media).
+type EntryMatcher struct {
+	ignore  *ignoreMatcher
+	include []entryPredicate
+	exclude []entryPredicate
+}
+
+// NewEntryMatcher loads root/.journalignore and compiles the -include and
+// -exclude predicate lists into an EntryMatcher.
+func NewEntryMatcher(root string, includes, excludes []string) (*EntryMatcher, error) {
+	ignore, err := loadJournalIgnore(root)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &EntryMatcher{ignore: ignore}
+	for _, raw := range includes {
+		p, err := parsePredicate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("-include %q: %w", raw, err)
+		}
+		m.include = append(m.include, p)
+	}
+	for _, raw := range excludes {
+		p, err := parsePredicate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("-exclude %q: %w", raw, err)
+		}
+		m.exclude = append(m.exclude, p)
+	}
+	return m, nil
+}
+
+// AllowEntry reports whether the HTML entry at path (rooted at root) should
+// be processed: not matched by .journalignore, matched by at least one
+// -include predicate when any are configured, and matched by no -exclude
+// predicate.
+func (m *EntryMatcher) AllowEntry(root, absPath string) bool {
+	if m == nil {
+		return true
+	}
+	relPath := absPath
+	if rel, err := filepath.Rel(root, absPath); err == nil {
+		relPath = rel
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	if m.ignore.Match(relPath) {
+		return false
+	}
+
+	var header entryHeader
+	var headerErr error
+	var peeked bool
+
+	if len(m.include) > 0 {
+		included := false
+		for _, p := range m.include {
+			if p.matches(relPath, absPath, &header, &headerErr, &peeked) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, p := range m.exclude {
+		if p.matches(relPath, absPath, &header, &headerErr, &peeked) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExcludesMedia reports whether the media reference relSrc (relative to the
+// Entries root, the same namespace AllowEntry's relPath uses) is excluded by
+// a glob -include/-exclude predicate. Date/title/tag predicates don't apply
+// to individual media files and are ignored here.
+func (m *EntryMatcher) ExcludesMedia(relSrc string) bool {
+	if m == nil {
+		return false
+	}
+	relSrc = filepath.ToSlash(relSrc)
+	base := filepath.Base(relSrc)
+
+	if m.ignore.Match(relSrc) {
+		return true
+	}
+	if len(m.include) > 0 {
+		included := false
+		for _, p := range m.include {
+			if p.kind != "glob" {
+				continue
+			}
+			if ok, _ := filepath.Match(p.glob, relSrc); ok {
+				included = true
+				break
+			}
+			if ok, _ := filepath.Match(p.glob, base); ok {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return true
+		}
+	}
+	for _, p := range m.exclude {
+		if p.kind != "glob" {
+			continue
+		}
+		if ok, _ := filepath.Match(p.glob, relSrc); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p.glob, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// stringListFlag implements flag.Value for repeatable "-include"/"-exclude" flags.
+type stringListFlag []string
+
+func (l *stringListFlag) String() string {
+	return fmt.Sprintf("%v", []string(*l))
+}
+
+func (l *stringListFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// --- Concurrent Processing Pipeline ---
+//
+// Source walks the entries directory and feeds Parse, which runs a pool of
+// workers over processEntryHTML; Parse feeds Write, which runs its own pool
+// over saveMarkdownFile. Media copies dominate wall time on large journals,
+// so running both stages concurrently is a substantial speedup. All three
+// stages drain and exit as soon as ctx is canceled.
+
+// Source walks root and emits the path of every .html file beneath it that
+// matcher allows on the returned channel, closing it once the walk
+// completes or ctx is canceled. Entries matcher rejects are skipped here,
+// before processEntryHTML ever opens them. resumeDone, if non-nil, is a set
+// of SourceFile base names a prior -manifest run already recorded; those
+// entries are skipped too, so re-running a conversion against the same
+// -manifest path resumes instead of redoing finished work.
+func Source(ctx context.Context, root string, matcher *EntryMatcher, resumeDone map[string]bool) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				log.Printf("Error accessing path %s: %v. Skipping.", path, walkErr)
+				return nil
+			}
+			if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".html") {
+				return nil
+			}
+			if !matcher.AllowEntry(root, path) {
+				log.Printf("Skipping entry excluded by .journalignore/-include/-exclude: %s", path)
+				return nil
+			}
+			if resumeDone[filepath.Base(path)] {
+				log.Printf("Skipping entry already present in prior manifest: %s", path)
+				return nil
+			}
+			select {
+			case out <- path:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error walking through entries directory %s: %v", root, err)
+		}
+	}()
+	return out
+}
+
+// Parse runs a pool of jobs workers that each call processEntryHTML on paths
+// from in, emitting the resulting entries on the returned channel. The
+// channel is closed once every worker has drained in or ctx is canceled.
+// Under dryRun, media is hashed but never copied into the output media store.
+func Parse(ctx context.Context, in <-chan string, entriesRoot string, outputDir string, matcher *EntryMatcher, dryRun bool, jobs int) <-chan MarkdownEntry {
+	out := make(chan MarkdownEntry)
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range in {
+				log.Printf("Processing entry: %s", path)
+				entry, err := processEntryHTML(path, entriesRoot, outputDir, matcher, dryRun)
+				if err != nil {
+					log.Printf("Error processing entry %s: %v. Entry skipped.", path, err)
+					continue
+				}
+				select {
+				case out <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// writeResult pairs the error (if any) from writing one entry with the
+// manifestEntry describing what was (or, under dryRun, would have been)
+// done for it.
+type writeResult struct {
+	err   error
+	entry manifestEntry
+}
+
+// Write runs a pool of jobs workers that each hand entries from in to
+// exporter.WriteEntry, emitting a writeResult per entry on the returned
+// channel. The channel is closed once every worker has drained in or ctx is
+// canceled. Under dryRun, exporter.WriteEntry is never called; only the
+// manifest entry is built.
+func Write(ctx context.Context, in <-chan MarkdownEntry, exporter Exporter, dryRun bool, jobs int) <-chan writeResult {
+	out := make(chan writeResult)
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			defer wg.Done()
+			for entry := range in {
+				var err error
+				if !dryRun {
+					err = exporter.WriteEntry(entry)
+				}
+				result := writeResult{err: err, entry: buildManifestEntry(entry, exporter)}
+				select {
+				case out <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// manifestEntry summarizes one entry for the -manifest/-dryrun output: its
+// source file, the output path its exporter wrote (or, under -dryrun, would
+// have written) it to, its media files' combined hashes and size, and any
+// parse warnings collected along the way.
+type manifestEntry struct {
+	SourceFile  string   `json:"source_file"`
+	OutputPath  string   `json:"output_path,omitempty"`
+	Title       string   `json:"title"`
+	Date        string   `json:"date"`
+	MediaHashes []string `json:"media_hashes,omitempty"`
+	MediaBytes  int64    `json:"media_bytes"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// manifest is the JSON document -manifest writes, and -dryrun prints to
+// stdout: one manifestEntry per processed HTML file.
+type manifest struct {
+	DryRun  bool            `json:"dry_run"`
+	Entries []manifestEntry `json:"entries"`
+}
+
+// buildManifestEntry computes entry's manifestEntry. Media byte counts are
+// read from the original source files (entry.Media's keys), since those are
+// identical whether or not storeMedia actually copied them.
+func buildManifestEntry(entry MarkdownEntry, exporter Exporter) manifestEntry {
+	me := manifestEntry{
+		SourceFile: entry.SourceFile,
+		Title:      entry.Title,
+		Date:       entry.CreationDate.Format("2006-01-02"),
+		Warnings:   entry.Warnings,
+	}
+	if planner, ok := exporter.(ManifestPlanner); ok {
+		me.OutputPath = planner.PlannedPath(entry)
+	}
+
+	hashes := make([]string, 0, len(entry.Media))
+	for src, mf := range entry.Media {
+		hashes = append(hashes, mf.SHA256)
+		if info, err := os.Stat(src); err == nil {
+			me.MediaBytes += info.Size()
+		}
+	}
+	sort.Strings(hashes)
+	me.MediaHashes = hashes
+	return me
+}
+
+// printDryRunTable prints the "source HTML -> planned output path -> media
+// count / bytes" summary -dryrun promises, one line per entry.
+func printDryRunTable(entries []manifestEntry) {
+	fmt.Println("SOURCE\tOUTPUT\tMEDIA")
+	for _, e := range entries {
+		fmt.Printf("%s\t%s\t%d files / %d bytes\n", e.SourceFile, e.OutputPath, len(e.MediaHashes), e.MediaBytes)
+	}
+}
 
 func main() {
 	inputZip := flag.String("i", "", "Input Apple Journal ZIP file path (required)")
 	outputDir := flag.String("o", "", "Output directory for Markdown files (required)")
+	frontMatterFlag := flag.String("frontmatter", "none", `Front matter format to emit at the top of each file: "yaml", "toml", or "none"`)
+	templatePath := flag.String("template", "", "Path to a Go text/template file controlling each entry's body (default: title heading + converted body)")
+	outFormat := flag.String("out-format", "", `Strftime-like output path template, e.g. "%Y/%m/%d-%H%M%S-{title}" (default: flat "<date>-<title>.md")`)
+	formatFlag := flag.String("format", "markdown", `Output format: "markdown", "obsidian", "dayone", or "gmi"`)
+	jobs := flag.Int("jobs", runtime.NumCPU(), "Number of concurrent workers for parsing and writing entries")
+	globals := stringMapFlag{}
+	flag.Var(globals, "global", "Global front matter/template value as key=value (repeatable)")
+	var includes, excludes stringListFlag
+	flag.Var(&includes, "include", `Only process entries matching this predicate (repeatable): a path glob, or "before:YYYY-MM-DD", "after:YYYY-MM-DD", "title:regex", "tag:regex"`)
+	flag.Var(&excludes, "exclude", `Skip entries matching this predicate (repeatable); same syntax as -include. Also applies to individual media files when the predicate is a glob`)
+	dryRun := flag.Bool("dryrun", false, "Run the full pipeline and report what would be written, without writing anything")
+	manifestPath := flag.String("manifest", "", "Write a JSON manifest of every entry processed (source file, output path, media hashes, parse warnings) to this path. If the path already exists, entries it already recorded are skipped, resuming an interrupted conversion")
 	flag.Parse()
 
 	if *inputZip == "" || *outputDir == "" {
@@ -281,11 +1584,57 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *jobs < 1 {
+		log.Fatalf("Invalid -jobs value %d, must be at least 1", *jobs)
+	}
+
+	frontMatterFormat := FrontMatterFormat(strings.ToLower(*frontMatterFlag))
+	switch frontMatterFormat {
+	case FrontMatterNone, FrontMatterYAML, FrontMatterTOML:
+	default:
+		log.Fatalf(`Invalid -frontmatter value %q, expected "yaml", "toml", or "none"`, *frontMatterFlag)
+	}
+
+	bodyTemplate, err := loadTemplate(*templatePath)
+	if err != nil {
+		log.Fatalf("Failed to load template: %v", err)
+	}
+
+	renderOpts := RenderOptions{
+		FrontMatter: frontMatterFormat,
+		Template:    bodyTemplate,
+		Globals:     globals,
+		OutFormat:   *outFormat,
+	}
+
+	var exporter Exporter
+	switch *formatFlag {
+	case "markdown":
+		exporter = &MarkdownExporter{OutputDir: *outputDir, Render: renderOpts}
+	case "obsidian":
+		exporter = &ObsidianExporter{OutputDir: *outputDir, Render: renderOpts}
+	case "dayone":
+		exporter = &DayOneExporter{OutputDir: *outputDir}
+	case "gmi":
+		exporter = &GmiExporter{OutputDir: *outputDir}
+	default:
+		log.Fatalf(`Invalid -format value %q, expected "markdown", "obsidian", "dayone", or "gmi"`, *formatFlag)
+	}
+
 	log.Printf("Starting conversion from %s to %s", *inputZip, *outputDir)
 
-	// 1. Create output directory if it doesn't exist
-	if err := os.MkdirAll(*outputDir, 0755); err != nil {
-		log.Fatalf("Failed to create output directory: %v", err)
+	// 1. Create output directory if it doesn't exist. Skipped entirely
+	// under -dryrun, which is never meant to touch outputDir.
+	if !*dryRun {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			log.Fatalf("Failed to create output directory: %v", err)
+		}
+
+		// Precreate the media store's shard directories so individual media
+		// files never pay for a MkdirAll call.
+		if err := prepOutput(*outputDir); err != nil {
+			log.Fatalf("Failed to prepare media store: %v", err)
+		}
 	}
 
 	// 2. Create temp directory for extraction
@@ -307,51 +1656,98 @@ func main() {
 
 	// 4. Find Entries path
 	entriesPath := filepath.Join(tempExtractDir, "Entries")
-    if _, err := os.Stat(entriesPath); os.IsNotExist(err) {
-        filesInTemp, _ := os.ReadDir(tempExtractDir)
-        if len(filesInTemp) == 1 && filesInTemp[0].IsDir() {
-            potentialRoot := filepath.Join(tempExtractDir, filesInTemp[0].Name(), "Entries")
-            if _, err := os.Stat(potentialRoot); err == nil {
-                entriesPath = potentialRoot
-            }
-        }
-    }
+	if _, err := os.Stat(entriesPath); os.IsNotExist(err) {
+		filesInTemp, _ := os.ReadDir(tempExtractDir)
+		if len(filesInTemp) == 1 && filesInTemp[0].IsDir() {
+			potentialRoot := filepath.Join(tempExtractDir, filesInTemp[0].Name(), "Entries")
+			if _, err := os.Stat(potentialRoot); err == nil {
+				entriesPath = potentialRoot
+			}
+		}
+	}
 
 	if _, err := os.Stat(entriesPath); os.IsNotExist(err) {
 		log.Fatalf("Entries folder not found at %s. Please ensure the zip structure is correct.", entriesPath)
 	}
 
-	// 5. Process entries
-	log.Printf("Processing HTML entries from: %s", entriesPath)
-	processedCount := 0
-	err = filepath.WalkDir(entriesPath, func(path string, d os.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			log.Printf("Error accessing path %s: %v. Skipping.", path, walkErr)
-			return nil
+	matcher, err := NewEntryMatcher(entriesPath, includes, excludes)
+	if err != nil {
+		log.Fatalf("Failed to build entry matcher: %v", err)
+	}
+
+	// Resume support: if -manifest already points at a manifest from a prior
+	// run, skip any entry it already recorded and seed the new manifest with
+	// its entries, so an interrupted conversion can pick up where it left
+	// off by re-running with the same flags.
+	man := manifest{DryRun: *dryRun}
+	resumeDone := map[string]bool{}
+	if *manifestPath != "" && !*dryRun {
+		if data, err := os.ReadFile(*manifestPath); err == nil {
+			var prior manifest
+			if err := json.Unmarshal(data, &prior); err != nil {
+				log.Fatalf("Failed to parse prior manifest %s: %v", *manifestPath, err)
+			}
+			if prior.DryRun {
+				// A dry-run manifest only previewed what would happen and
+				// wrote nothing; trusting it here would make every
+				// previewed entry look already-done and silently skip it.
+				log.Printf("Ignoring prior manifest %s for resume: it was written by -dryrun and nothing was actually exported", *manifestPath)
+			} else {
+				man.Entries = append(man.Entries, prior.Entries...)
+				for _, e := range prior.Entries {
+					resumeDone[e.SourceFile] = true
+				}
+				log.Printf("Resuming from prior manifest %s: %d entries already done", *manifestPath, len(prior.Entries))
+			}
 		}
-		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".html") {
-			return nil
+	}
+
+	// 5. Process entries through the concurrent Source -> Parse -> Write pipeline
+	log.Printf("Processing HTML entries from: %s (jobs=%d)", entriesPath, *jobs)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	paths := Source(ctx, entriesPath, matcher, resumeDone)
+	entries := Parse(ctx, paths, entriesPath, *outputDir, matcher, *dryRun, *jobs)
+	results := Write(ctx, entries, exporter, *dryRun, *jobs)
+
+	processedCount := 0
+	for res := range results {
+		if res.err != nil {
+			log.Printf("Error exporting entry: %v", res.err)
+			continue
 		}
+		man.Entries = append(man.Entries, res.entry)
+		processedCount++
+	}
+	sort.Slice(man.Entries, func(i, j int) bool { return man.Entries[i].SourceFile < man.Entries[j].SourceFile })
 
-		log.Printf("Processing entry: %s", path)
-		entry, procErr := processEntryHTML(path, "") // baseResourcesPath is not used anymore
-		if procErr != nil {
-			log.Printf("Error processing entry %s: %v. Entry skipped.", path, procErr)
-			return nil
+	if !*dryRun {
+		if err := exporter.Finalize(); err != nil {
+			log.Printf("Error finalizing export: %v", err)
 		}
+	}
 
-		if err := saveMarkdownFile(*outputDir, entry); err != nil {
-			log.Printf("Error saving markdown file for %s: %v", path, err)
+	if *dryRun {
+		printDryRunTable(man.Entries)
+		data, err := json.MarshalIndent(man, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal dry-run manifest: %v", err)
 		}
-		processedCount++
-		return nil
-	})
+		fmt.Println(string(data))
+	}
 
-	if err != nil {
-		log.Fatalf("Error walking through entries directory %s: %v", entriesPath, err)
+	if *manifestPath != "" {
+		data, err := json.MarshalIndent(man, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal manifest: %v", err)
+		}
+		if err := os.WriteFile(*manifestPath, data, 0644); err != nil {
+			log.Fatalf("Failed to write manifest %s: %v", *manifestPath, err)
+		}
+		log.Printf("Manifest written to %s", *manifestPath)
 	}
 
 	log.Printf("Conversion complete! Processed %d entries.", processedCount)
 	log.Printf("Output written to: %s", *outputDir)
 }
-
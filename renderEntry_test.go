@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestRenderEntryExposesGlobalsToTemplate(t *testing.T) {
+	tmpl, err := template.New("entry").Parse("{{.Globals.site}}: {{.Title}}")
+	if err != nil {
+		t.Fatalf("parsing template: %v", err)
+	}
+
+	entry := MarkdownEntry{Title: "Hello"}
+	opts := RenderOptions{
+		FrontMatter: FrontMatterNone,
+		Template:    tmpl,
+		Globals:     map[string]string{"site": "My Journal"},
+	}
+
+	out, err := renderEntry(entry, opts)
+	if err != nil {
+		t.Fatalf("renderEntry: %v", err)
+	}
+
+	want := "My Journal: Hello"
+	if out != want {
+		t.Fatalf("renderEntry output = %q, want %q", out, want)
+	}
+}
+
+func TestDefaultBodyTemplateOmitsHeadingForUntitledEntries(t *testing.T) {
+	tmpl, err := loadTemplate("")
+	if err != nil {
+		t.Fatalf("loadTemplate: %v", err)
+	}
+
+	entry := MarkdownEntry{Title: "", MarkdownText: "hello world"}
+	out, err := renderEntry(entry, RenderOptions{FrontMatter: FrontMatterNone, Template: tmpl})
+	if err != nil {
+		t.Fatalf("renderEntry: %v", err)
+	}
+
+	if strings.Contains(out, "#") {
+		t.Fatalf("expected no heading for an untitled entry, got %q", out)
+	}
+	if out != "hello world" {
+		t.Fatalf("renderEntry output = %q, want %q", out, "hello world")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreMediaDeduplicatesByContent(t *testing.T) {
+	outputDir := t.TempDir()
+	if err := prepOutput(outputDir); err != nil {
+		t.Fatalf("prepOutput: %v", err)
+	}
+
+	srcA := filepath.Join(t.TempDir(), "a.jpg")
+	srcB := filepath.Join(t.TempDir(), "b.jpg")
+	if err := os.WriteFile(srcA, []byte("same bytes"), 0644); err != nil {
+		t.Fatalf("writing srcA: %v", err)
+	}
+	if err := os.WriteFile(srcB, []byte("same bytes"), 0644); err != nil {
+		t.Fatalf("writing srcB: %v", err)
+	}
+
+	mfA, err := storeMedia(srcA, outputDir, false)
+	if err != nil {
+		t.Fatalf("storeMedia(srcA): %v", err)
+	}
+	mfB, err := storeMedia(srcB, outputDir, false)
+	if err != nil {
+		t.Fatalf("storeMedia(srcB): %v", err)
+	}
+
+	if mfA.SHA256 != mfB.SHA256 || mfA.Path != mfB.Path {
+		t.Fatalf("identical content stored at different locations: %+v vs %+v", mfA, mfB)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(outputDir, "media", "*", "*"+filepath.Ext(mfA.Path)))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected exactly one stored file for deduplicated content, found %d: %v", len(matches), matches)
+	}
+}
+
+func TestStoreMediaDryRunNeverWrites(t *testing.T) {
+	outputDir := t.TempDir()
+	if err := prepOutput(outputDir); err != nil {
+		t.Fatalf("prepOutput: %v", err)
+	}
+
+	src := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(src, []byte("dry run content"), 0644); err != nil {
+		t.Fatalf("writing src: %v", err)
+	}
+
+	mf, err := storeMedia(src, outputDir, true)
+	if err != nil {
+		t.Fatalf("storeMedia dryRun: %v", err)
+	}
+	if mf.SHA256 == "" || mf.Path == "" {
+		t.Fatalf("expected a populated hash and path even under dryRun, got %+v", mf)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(outputDir, "media", "*", "*"))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Fatalf("dryRun must not write to the media store, found: %v", matches)
+	}
+}